@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Role is a collaborator's permission level on a shared playlist.
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+// roleOf returns the caller's role on playlist: the creator is always the
+// owner, otherwise whatever role (if any) is recorded in Collaborators.
+func roleOf(playlist Playlist, userID string) (Role, bool) {
+	if playlist.UserID == userID {
+		return RoleOwner, true
+	}
+	role, ok := playlist.Collaborators[userID]
+	return role, ok
+}
+
+type aclRequest struct {
+	User string `json:"user"`
+	Role Role   `json:"role"`
+}
+
+// PlaylistACL handles POST and DELETE /playlist/acl?playlistId=...,
+// granting or revoking a collaborator's role. Only the playlist owner may
+// manage its ACL.
+func (api *MusicListerAPI) PlaylistACL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	playlistID := r.URL.Query().Get("playlistId")
+	caller := userFromContext(r)
+
+	playlist, exists, err := api.Store.Playlists().Get(ctx, playlistID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Playlist not found", http.StatusNotFound)
+		return
+	}
+
+	if role, _ := roleOf(playlist, caller.ID); role != RoleOwner {
+		http.Error(w, "Only the playlist owner can manage collaborators", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var body aclRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if body.User == "" || (body.Role != RoleEditor && body.Role != RoleViewer) {
+			http.Error(w, "user and a valid role (editor or viewer) are required", http.StatusBadRequest)
+			return
+		}
+
+		if playlist.Collaborators == nil {
+			playlist.Collaborators = make(map[string]Role)
+		}
+		playlist.Collaborators[body.User] = body.Role
+
+		if err := api.Store.Playlists().Save(ctx, playlist); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(playlist)
+
+	case http.MethodDelete:
+		targetUser := r.URL.Query().Get("user")
+		delete(playlist.Collaborators, targetUser)
+
+		if err := api.Store.Playlists().Save(ctx, playlist); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
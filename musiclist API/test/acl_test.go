@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoleOfOwnerAndCollaborators(t *testing.T) {
+	playlist := Playlist{
+		UserID:        "owner-1",
+		Collaborators: map[string]Role{"editor-1": RoleEditor},
+	}
+
+	if role, ok := roleOf(playlist, "owner-1"); !ok || role != RoleOwner {
+		t.Fatalf("roleOf(owner) = (%v, %v), want (%v, true)", role, ok, RoleOwner)
+	}
+	if role, ok := roleOf(playlist, "editor-1"); !ok || role != RoleEditor {
+		t.Fatalf("roleOf(editor) = (%v, %v), want (%v, true)", role, ok, RoleEditor)
+	}
+	if _, ok := roleOf(playlist, "stranger"); ok {
+		t.Fatal("expected roleOf to report no role for an uninvolved user")
+	}
+}
+
+// withUser attaches user to the request context the way RequireAuth does,
+// so handlers that call userFromContext work in tests without going through
+// the JWT middleware.
+func withUser(r *http.Request, user *User) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+}
+
+func TestPlaylistACLRejectsNonOwner(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+
+	owner := User{ID: "owner-1"}
+	intruder := User{ID: "intruder-1"}
+	playlist := Playlist{ID: "playlist-1", UserID: owner.ID}
+	if err := store.Playlists().Save(context.Background(), playlist); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/playlist/acl?playlistId=playlist-1", nil)
+	req = withUser(req, &intruder)
+	rec := httptest.NewRecorder()
+
+	api.PlaylistACL(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestPlaylistWebSocketRejectsUninvitedUser(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+
+	owner := User{ID: "owner-1"}
+	stranger := User{ID: "stranger-1"}
+	playlist := Playlist{ID: "playlist-1", UserID: owner.ID}
+	if err := store.Playlists().Save(context.Background(), playlist); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ws/playlist?playlistId=playlist-1", nil)
+	req = withUser(req, &stranger)
+	rec := httptest.NewRecorder()
+
+	api.PlaylistWebSocket(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
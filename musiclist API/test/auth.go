@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+type contextKey string
+
+const userContextKey contextKey = "musiclister_user"
+
+// jwtSigningKey returns the HMAC signing key from MUSICLISTER_JWT_SECRET.
+// There is deliberately no hardcoded fallback: a known-public default would
+// let anyone forge tokens for any user_id, so a deployment that forgets to
+// set the env var must fail to start (see main) rather than boot insecurely.
+func jwtSigningKey() []byte {
+	key := os.Getenv("MUSICLISTER_JWT_SECRET")
+	if key == "" {
+		panic("MUSICLISTER_JWT_SECRET must be set")
+	}
+	return []byte(key)
+}
+
+type musicListerClaims struct {
+	UserID string `json:"user_id"`
+	Type   string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// signToken issues a signed JWT of the given type (tokenTypeAccess or
+// tokenTypeRefresh) for userID, valid for ttl.
+func signToken(userID, tokenType string, ttl time.Duration) (string, error) {
+	claims := musicListerClaims{
+		UserID: userID,
+		Type:   tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSigningKey())
+}
+
+// parseToken validates a signed JWT, checks that it carries the expected
+// token type (so an access token can't be replayed as a refresh token or
+// vice versa), and returns the embedded user ID.
+func parseToken(tokenString, expectedType string) (string, error) {
+	var claims musicListerClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (interface{}, error) {
+		return jwtSigningKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return "", fmt.Errorf("invalid token")
+	}
+	if claims.Type != expectedType {
+		return "", fmt.Errorf("wrong token type")
+	}
+	return claims.UserID, nil
+}
+
+// hashPassword bcrypt-hashes a plaintext password for storage.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// RequireAuth wraps handler so it only runs once the Authorization header
+// carries a valid Bearer token, resolving it to a *User stored in the
+// request context.
+func (api *MusicListerAPI) RequireAuth(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := parseToken(strings.TrimPrefix(header, "Bearer "), tokenTypeAccess)
+		if err != nil {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		user, exists, err := api.Store.Users().Get(r.Context(), userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "User not found", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, &user)
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// userFromContext retrieves the *User injected by RequireAuth.
+func userFromContext(r *http.Request) *User {
+	user, _ := r.Context().Value(userContextKey).(*User)
+	return user
+}
+
+type loginRequest struct {
+	Email    string
+	Password string
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Login handles POST /login, verifying email+password and issuing a signed
+// access/refresh token pair.
+func (api *MusicListerAPI) Login(w http.ResponseWriter, r *http.Request) {
+	var body loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, exists, err := api.Store.Users().GetByEmail(r.Context(), body.Email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(body.Password)) != nil {
+		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		return
+	}
+
+	writeTokenPair(w, user.ID)
+}
+
+// RefreshToken handles POST /refresh, exchanging a still-valid refresh token
+// for a new access/refresh token pair.
+func (api *MusicListerAPI) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := parseToken(body.RefreshToken, tokenTypeRefresh)
+	if err != nil {
+		http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	writeTokenPair(w, userID)
+}
+
+func writeTokenPair(w http.ResponseWriter, userID string) {
+	accessToken, err := signToken(userID, tokenTypeAccess, accessTokenTTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	refreshToken, err := signToken(userID, tokenTypeRefresh, refreshTokenTTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	})
+}
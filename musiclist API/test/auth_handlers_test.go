@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegisterLoginAndRequireAuthFlow(t *testing.T) {
+	withJWTSecret(t, "test-secret")
+
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+
+	registerBody, _ := json.Marshal(registerRequest{Name: "Ada", Email: "ada@example.com", Password: "hunter2"})
+	registerReq := httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader(registerBody))
+	registerRec := httptest.NewRecorder()
+	api.RegisterUser(registerRec, registerReq)
+	if registerRec.Code != http.StatusCreated {
+		t.Fatalf("RegisterUser status = %d, want %d: %s", registerRec.Code, http.StatusCreated, registerRec.Body)
+	}
+
+	loginBody, _ := json.Marshal(loginRequest{Email: "ada@example.com", Password: "hunter2"})
+	loginReq := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(loginBody))
+	loginRec := httptest.NewRecorder()
+	api.Login(loginRec, loginReq)
+	if loginRec.Code != http.StatusOK {
+		t.Fatalf("Login status = %d, want %d: %s", loginRec.Code, http.StatusOK, loginRec.Body)
+	}
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(loginRec.Body).Decode(&tokens); err != nil {
+		t.Fatalf("decode tokens: %v", err)
+	}
+
+	var authorizedUser *User
+	protected := api.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		authorizedUser = userFromContext(r)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	profileReq := httptest.NewRequest(http.MethodGet, "/ViewProfile", nil)
+	profileReq.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	profileRec := httptest.NewRecorder()
+	protected(profileRec, profileReq)
+
+	if profileRec.Code != http.StatusOK {
+		t.Fatalf("RequireAuth status = %d, want %d", profileRec.Code, http.StatusOK)
+	}
+	if authorizedUser == nil || authorizedUser.Email != "ada@example.com" {
+		t.Fatalf("RequireAuth injected user = %+v, want ada@example.com", authorizedUser)
+	}
+
+	// A refresh token must not be usable as an access token.
+	refreshAsAccessReq := httptest.NewRequest(http.MethodGet, "/ViewProfile", nil)
+	refreshAsAccessReq.Header.Set("Authorization", "Bearer "+tokens.RefreshToken)
+	refreshAsAccessRec := httptest.NewRecorder()
+	protected(refreshAsAccessRec, refreshAsAccessReq)
+	if refreshAsAccessRec.Code != http.StatusUnauthorized {
+		t.Fatalf("RequireAuth with refresh token status = %d, want %d", refreshAsAccessRec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestLoginRejectsWrongPassword(t *testing.T) {
+	withJWTSecret(t, "test-secret")
+
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+
+	hash, err := hashPassword("correct-password")
+	if err != nil {
+		t.Fatalf("hashPassword: %v", err)
+	}
+	user := User{ID: "user-1", SecretCode: "secret-1", Email: "ada@example.com", PasswordHash: hash}
+	if err := store.Users().Save(context.Background(), user); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loginBody, _ := json.Marshal(loginRequest{Email: "ada@example.com", Password: "wrong-password"})
+	loginReq := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(loginBody))
+	loginRec := httptest.NewRecorder()
+	api.Login(loginRec, loginReq)
+
+	if loginRec.Code != http.StatusUnauthorized {
+		t.Fatalf("Login status = %d, want %d", loginRec.Code, http.StatusUnauthorized)
+	}
+}
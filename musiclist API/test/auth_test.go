@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func withJWTSecret(t *testing.T, secret string) {
+	t.Helper()
+	previous, had := os.LookupEnv("MUSICLISTER_JWT_SECRET")
+	if secret == "" {
+		os.Unsetenv("MUSICLISTER_JWT_SECRET")
+	} else {
+		os.Setenv("MUSICLISTER_JWT_SECRET", secret)
+	}
+	t.Cleanup(func() {
+		if had {
+			os.Setenv("MUSICLISTER_JWT_SECRET", previous)
+		} else {
+			os.Unsetenv("MUSICLISTER_JWT_SECRET")
+		}
+	})
+}
+
+func TestJWTSigningKeyPanicsWithoutSecret(t *testing.T) {
+	withJWTSecret(t, "")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected jwtSigningKey to panic when MUSICLISTER_JWT_SECRET is unset")
+		}
+	}()
+	jwtSigningKey()
+}
+
+func TestSignAndParseToken(t *testing.T) {
+	withJWTSecret(t, "test-secret")
+
+	token, err := signToken("user-1", tokenTypeAccess, time.Minute)
+	if err != nil {
+		t.Fatalf("signToken: %v", err)
+	}
+
+	userID, err := parseToken(token, tokenTypeAccess)
+	if err != nil {
+		t.Fatalf("parseToken: %v", err)
+	}
+	if userID != "user-1" {
+		t.Fatalf("got user ID %q, want %q", userID, "user-1")
+	}
+}
+
+func TestParseTokenRejectsWrongType(t *testing.T) {
+	withJWTSecret(t, "test-secret")
+
+	accessToken, err := signToken("user-1", tokenTypeAccess, time.Minute)
+	if err != nil {
+		t.Fatalf("signToken: %v", err)
+	}
+
+	if _, err := parseToken(accessToken, tokenTypeRefresh); err == nil {
+		t.Fatal("expected an access token to be rejected when a refresh token is expected")
+	}
+
+	refreshToken, err := signToken("user-1", tokenTypeRefresh, time.Minute)
+	if err != nil {
+		t.Fatalf("signToken: %v", err)
+	}
+	if _, err := parseToken(refreshToken, tokenTypeAccess); err == nil {
+		t.Fatal("expected a refresh token to be rejected when an access token is expected")
+	}
+}
+
+func TestHashPasswordRoundTrips(t *testing.T) {
+	hash, err := hashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("hashPassword: %v", err)
+	}
+	if hash == "correct horse battery staple" {
+		t.Fatal("hashPassword did not hash the input")
+	}
+}
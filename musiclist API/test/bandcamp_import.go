@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const bandcampSearchURL = "https://bandcamp.com/api/bcsearch_public_api/1/autocomplete_elastic"
+
+type bandcampSearchRequest struct {
+	SearchText string `json:"search_text"`
+	Fullpage   bool   `json:"fullpage"`
+}
+
+type bandcampSearchResponse struct {
+	Auto struct {
+		Results []struct {
+			Type     string `json:"type"`
+			Name     string `json:"name"`
+			BandName string `json:"band_name"`
+			URL      string `json:"item_url_root"`
+		} `json:"results"`
+	} `json:"auto"`
+}
+
+// resolveBandcampAlbum looks up an album by artist and name using the public
+// Bandcamp search endpoint and returns its canonical URL.
+func resolveBandcampAlbum(artist, album string) (string, error) {
+	body, err := json.Marshal(bandcampSearchRequest{SearchText: artist + " " + album})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.Post(bandcampSearchURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result bandcampSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	for _, match := range result.Auto.Results {
+		if match.Type == "a" && match.Name == album {
+			return match.URL, nil
+		}
+	}
+	return "", fmt.Errorf("no Bandcamp album found for %q by %q", album, artist)
+}
+
+// ImportBandcampAlbum handles POST /import/bandcamp, resolving an
+// artist+album to its Bandcamp URL and storing it as a single-song playlist
+// entry for the authenticated user.
+func (api *MusicListerAPI) ImportBandcampAlbum(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	artist := r.URL.Query().Get("artist")
+	album := r.URL.Query().Get("album")
+	user := userFromContext(r)
+
+	if artist == "" || album == "" {
+		http.Error(w, "artist and album are required", http.StatusBadRequest)
+		return
+	}
+
+	albumURL, err := resolveBandcampAlbum(artist, album)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	song := Song{
+		ID:        generateUniqueID(),
+		Name:      album,
+		Composers: artist,
+		MusicURL:  albumURL,
+	}
+	if err := api.Store.Songs().Save(ctx, song); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	playlist := Playlist{
+		ID:     generateUniqueID(),
+		Name:   album,
+		Songs:  []Song{song},
+		UserID: user.ID,
+	}
+	if err := api.Store.Playlists().Save(ctx, playlist); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(playlist)
+}
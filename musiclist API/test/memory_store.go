@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryDataStore is an in-memory DataStore backed by plain maps guarded by
+// a single RWMutex. It is the default store used by tests and by local
+// development when no SQLite path is configured.
+type memoryDataStore struct {
+	mutex     sync.RWMutex
+	users     map[string]User
+	playlists map[string]Playlist
+	songs     map[string]Song
+}
+
+// NewMemoryDataStore creates an empty in-memory DataStore.
+func NewMemoryDataStore() DataStore {
+	return &memoryDataStore{
+		users:     make(map[string]User),
+		playlists: make(map[string]Playlist),
+		songs:     make(map[string]Song),
+	}
+}
+
+func (s *memoryDataStore) Users() UserStore         { return (*memoryUserStore)(s) }
+func (s *memoryDataStore) Playlists() PlaylistStore { return (*memoryPlaylistStore)(s) }
+func (s *memoryDataStore) Songs() SongStore         { return (*memorySongStore)(s) }
+
+type memoryUserStore memoryDataStore
+
+func (s *memoryUserStore) Get(ctx context.Context, id string) (User, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, true, nil
+		}
+	}
+	return User{}, false, nil
+}
+
+func (s *memoryUserStore) GetByEmail(ctx context.Context, email string) (User, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, true, nil
+		}
+	}
+	return User{}, false, nil
+}
+
+func (s *memoryUserStore) GetByName(ctx context.Context, name string) (User, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, user := range s.users {
+		if user.Name == name {
+			return user, true, nil
+		}
+	}
+	return User{}, false, nil
+}
+
+func (s *memoryUserStore) Save(ctx context.Context, user User) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.users[user.SecretCode] = user
+	return nil
+}
+
+type memoryPlaylistStore memoryDataStore
+
+func (s *memoryPlaylistStore) Get(ctx context.Context, id string) (Playlist, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	playlist, exists := s.playlists[id]
+	return playlist, exists, nil
+}
+
+func (s *memoryPlaylistStore) List(ctx context.Context) ([]Playlist, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	playlists := make([]Playlist, 0, len(s.playlists))
+	for _, playlist := range s.playlists {
+		playlists = append(playlists, playlist)
+	}
+	return playlists, nil
+}
+
+func (s *memoryPlaylistStore) Save(ctx context.Context, playlist Playlist) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.playlists[playlist.ID] = playlist
+	return nil
+}
+
+func (s *memoryPlaylistStore) Delete(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.playlists, id)
+	return nil
+}
+
+type memorySongStore memoryDataStore
+
+func (s *memorySongStore) Get(ctx context.Context, id string) (Song, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	song, exists := s.songs[id]
+	return song, exists, nil
+}
+
+func (s *memorySongStore) Save(ctx context.Context, song Song) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.songs[song.ID] = song
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryUserStoreLookups(t *testing.T) {
+	store := NewMemoryDataStore()
+	ctx := context.Background()
+
+	user := User{ID: "user-1", SecretCode: "secret-1", Name: "Ada", Email: "ada@example.com"}
+	if err := store.Users().Save(ctx, user); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if got, exists, err := store.Users().Get(ctx, "user-1"); err != nil || !exists || got.ID != "user-1" {
+		t.Fatalf("Get(user-1) = (%+v, %v, %v)", got, exists, err)
+	}
+	if got, exists, err := store.Users().GetByEmail(ctx, "ada@example.com"); err != nil || !exists || got.ID != "user-1" {
+		t.Fatalf("GetByEmail = (%+v, %v, %v)", got, exists, err)
+	}
+	if got, exists, err := store.Users().GetByName(ctx, "Ada"); err != nil || !exists || got.ID != "user-1" {
+		t.Fatalf("GetByName = (%+v, %v, %v)", got, exists, err)
+	}
+	if _, exists, err := store.Users().GetByEmail(ctx, "missing@example.com"); err != nil || exists {
+		t.Fatalf("GetByEmail(missing) = (_, %v, %v), want (_, false, nil)", exists, err)
+	}
+}
+
+func TestMemoryPlaylistStoreCRUD(t *testing.T) {
+	store := NewMemoryDataStore()
+	ctx := context.Background()
+
+	playlist := Playlist{ID: "playlist-1", Name: "Favorites", UserID: "user-1"}
+	if err := store.Playlists().Save(ctx, playlist); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, exists, err := store.Playlists().Get(ctx, "playlist-1")
+	if err != nil || !exists || got.Name != "Favorites" {
+		t.Fatalf("Get = (%+v, %v, %v)", got, exists, err)
+	}
+
+	all, err := store.Playlists().List(ctx)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("List = (%v, %v), want one playlist", all, err)
+	}
+
+	if err := store.Playlists().Delete(ctx, "playlist-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, exists, err := store.Playlists().Get(ctx, "playlist-1"); err != nil || exists {
+		t.Fatalf("Get after delete = (_, %v, %v), want (_, false, nil)", exists, err)
+	}
+}
+
+func TestMemoryPlaylistStoreSavesEmbeddedSongs(t *testing.T) {
+	store := NewMemoryDataStore()
+	ctx := context.Background()
+
+	playlist := Playlist{
+		ID:     "playlist-1",
+		Name:   "Favorites",
+		UserID: "user-1",
+		Songs:  []Song{{ID: "song-1", Name: "Clair de Lune", Composers: "Debussy"}},
+	}
+	if err := store.Playlists().Save(ctx, playlist); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, exists, err := store.Playlists().Get(ctx, "playlist-1")
+	if err != nil || !exists {
+		t.Fatalf("Get = (%+v, %v, %v)", got, exists, err)
+	}
+	if len(got.Songs) != 1 || got.Songs[0].ID != "song-1" {
+		t.Fatalf("Songs = %+v, want one song with ID song-1", got.Songs)
+	}
+}
+
+func TestMemorySongStoreCRUD(t *testing.T) {
+	store := NewMemoryDataStore()
+	ctx := context.Background()
+
+	song := Song{ID: "song-1", Name: "Clair de Lune", Composers: "Debussy"}
+	if err := store.Songs().Save(ctx, song); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, exists, err := store.Songs().Get(ctx, "song-1")
+	if err != nil || !exists || got.Name != "Clair de Lune" {
+		t.Fatalf("Get = (%+v, %v, %v)", got, exists, err)
+	}
+	if _, exists, err := store.Songs().Get(ctx, "missing"); err != nil || exists {
+		t.Fatalf("Get(missing) = (_, %v, %v), want (_, false, nil)", exists, err)
+	}
+}
@@ -1,242 +1,316 @@
-package main
-
-import (
-	"encoding/json"
-	"fmt"
-	"github.com/google/uuid"
-	"net/http"
-	"sync"
-)
-
-// User represents a user in the MusicLister application.
-type User struct {
-	ID         string
-	SecretCode string
-	Name       string
-	Email      string
-	Playlists  []Playlist
-}
-
-// Playlist represents a playlist in the MusicLister applicataion.
-type Playlist struct {
-	ID     string
-	Name   string
-	Songs  []Song
-	UserID string
-}
-
-// Song represents a song in the MusicLister application.
-type Song struct {
-	ID        string
-	Name      string
-	Composers string
-	MusicURL  string
-}
-
-// MusicListerAPI is the main struct that holds the data and provides HTTP handlers.
-type MusicListerAPI struct {
-	Users     map[string]User
-	Playlists map[string]Playlist
-	Songs     map[string]Song
-	Mutex     sync.RWMutex
-}
-
-// NewMusicListerAPI creates a new instance of the MusicListerAPI.
-func NewMusicListerAPI() *MusicListerAPI {
-	return &MusicListerAPI{
-		Users:     make(map[string]User),
-		Playlists: make(map[string]Playlist),
-		Songs:     make(map[string]Song),
-	}
-}
-
-// RegisterUser handles user registration.
-func (api *MusicListerAPI) RegisterUser(w http.ResponseWriter, r *http.Request) {
-	var newUser User
-	err := json.NewDecoder(r.Body).Decode(&newUser)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	if newUser.Name == "" || newUser.Email == "" {
-		http.Error(w, "Name and Email are required", http.StatusBadRequest)
-		return
-	}
-
-	api.Mutex.Lock()
-	defer api.Mutex.Unlock()
-
-	// Check if user with the same email already exists
-	for _, user := range api.Users {
-		if user.Email == newUser.Email {
-			http.Error(w, "User with this email already exists", http.StatusBadRequest)
-			return
-		}
-	}
-
-	newUser.ID = generateUniqueID()
-	newUser.SecretCode = generateUniqueID()
-	api.Users[newUser.SecretCode] = newUser
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(newUser)
-}
-
-// LoginUser handles user login.
-func (api *MusicListerAPI) LoginUser(w http.ResponseWriter, r *http.Request) {
-	secretCode := r.URL.Query().Get("secretCode")
-
-	api.Mutex.RLock()
-	defer api.Mutex.RUnlock()
-
-	user, exists := api.Users[secretCode]
-	if exists {
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(user)
-	} else {
-		http.Error(w, "User not found", http.StatusNotFound)
-	}
-}
-
-// ViewProfile handles viewing user profiles.
-func (api *MusicListerAPI) ViewProfile(w http.ResponseWriter, r *http.Request) {
-	secretCode := r.URL.Query().Get("secretCode")
-
-	api.Mutex.RLock()
-	defer api.Mutex.RUnlock()
-
-	user, exists := api.Users[secretCode]
-	if exists {
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(user)
-	} else {
-		http.Error(w, "User not found", http.StatusNotFound)
-	}
-}
-
-// GetAllSongsOfPlaylist handles getting all songs of a playlist.
-func (api *MusicListerAPI) GetAllSongsOfPlaylist(w http.ResponseWriter, r *http.Request) {
-	playlistID := r.URL.Query().Get("playlistId")
-
-	api.Mutex.RLock()
-	defer api.Mutex.RUnlock()
-
-	playlist, exists := api.Playlists[playlistID]
-	if exists {
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(playlist.Songs)
-	} else {
-		http.Error(w, "Playlist not found", http.StatusNotFound)
-	}
-}
-
-// CreatePlaylist handles creating a new playlist.
-func (api *MusicListerAPI) CreatePlaylist(w http.ResponseWriter, r *http.Request) {
-	secretCode := r.URL.Query().Get("secretCode")
-
-	api.Mutex.Lock()
-	defer api.Mutex.Unlock()
-
-	user, exists := api.Users[secretCode]
-	if !exists {
-		http.Error(w, "User not found", http.StatusNotFound)
-		return
-	}
-
-	var newPlaylist Playlist
-	err := json.NewDecoder(r.Body).Decode(&newPlaylist)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	newPlaylist.ID = generateUniqueID()
-	newPlaylist.UserID = user.ID
-	api.Playlists[newPlaylist.ID] = newPlaylist
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(newPlaylist)
-}
-
-// DeletePlaylist handles deleting a playlist.
-func (api *MusicListerAPI) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
-	playlistID := r.URL.Query().Get("playlistId")
-
-	api.Mutex.Lock()
-	defer api.Mutex.Unlock()
-
-	_, exists := api.Playlists[playlistID]
-	if exists {
-		delete(api.Playlists, playlistID)
-		w.WriteHeader(http.StatusNoContent)
-	} else {
-		http.Error(w, "Playlist not found", http.StatusNotFound)
-	}
-}
-
-// GetSongDetail handles getting details of a song.
-func (api *MusicListerAPI) GetSongDetail(w http.ResponseWriter, r *http.Request) {
-	songID := r.URL.Query().Get("songId")
-
-	api.Mutex.RLock()
-	defer api.Mutex.RUnlock()
-
-	song, exists := api.Songs[songID]
-	if exists {
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(song)
-	} else {
-		http.Error(w, "Song not found", http.StatusNotFound)
-	}
-}
-
-// addSongToPlaylist handles adding a song to a playlist.
-func (api *MusicListerAPI) addSongToPlaylist(w http.ResponseWriter, r *http.Request) {
-	playlistID := r.URL.Query().Get("playlistId")
-
-	api.Mutex.Lock()
-	defer api.Mutex.Unlock()
-
-	playlist, exists := api.Playlists[playlistID]
-	if !exists {
-		http.Error(w, "Playlist not found", http.StatusNotFound)
-		return
-	}
-
-	var newSong Song
-	err := json.NewDecoder(r.Body).Decode(&newSong)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	newSong.ID = generateUniqueID()
-	playlist.Songs = append(playlist.Songs, newSong)
-	api.Playlists[playlistID] = playlist
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(playlist)
-}
-
-// generateUniqueID generates a unique ID using the UUID library.
-func generateUniqueID() string {
-	id := uuid.New()
-	return id.String()
-}
-
-func main() {
-	api := NewMusicListerAPI()
-
-	http.HandleFunc("/register", api.RegisterUser)
-	http.HandleFunc("/login", api.LoginUser)
-	http.HandleFunc("/ViewProfile", api.ViewProfile)
-	http.HandleFunc("/getAllSongsOfPlaylist", api.GetAllSongsOfPlaylist)
-	http.HandleFunc("/createPlaylist", api.CreatePlaylist)
-	http.HandleFunc("/deletePlaylist", api.DeletePlaylist)
-	http.HandleFunc("/getSongDetail", api.GetSongDetail)
-	http.HandleFunc("/addSongToPlaylist", api.addSongToPlaylist)
-
-	fmt.Println("Server is running on :8080")
-	http.ListenAndServe(":8080", nil)
-}
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// User represents a user in the MusicLister application.
+type User struct {
+	ID           string
+	SecretCode   string
+	Name         string
+	Email        string
+	PasswordHash string `json:"-"`
+	Playlists    []Playlist
+	SpotifyToken *SpotifyToken
+}
+
+// SpotifyToken holds a user's OAuth credentials for the Spotify Web API,
+// obtained via the authorization-code flow in import.go.
+type SpotifyToken struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// Playlist represents a playlist in the MusicLister applicataion.
+type Playlist struct {
+	ID            string
+	Name          string
+	Songs         []Song
+	UserID        string
+	Collaborators map[string]Role
+}
+
+// Song represents a song in the MusicLister application.
+type Song struct {
+	ID        string
+	Name      string
+	Composers string
+	MusicURL  string
+}
+
+// MusicListerAPI is the main struct that holds the data and provides HTTP handlers.
+type MusicListerAPI struct {
+	Store         DataStore
+	Hub           *playlistHub
+	SpotifyStates *spotifyOAuthStates
+}
+
+// NewMusicListerAPI creates a new instance of the MusicListerAPI backed by store.
+func NewMusicListerAPI(store DataStore) *MusicListerAPI {
+	return &MusicListerAPI{Store: store, Hub: newPlaylistHub(), SpotifyStates: newSpotifyOAuthStates()}
+}
+
+// registerRequest is the payload RegisterUser decodes; Password never
+// touches the User struct directly so it can't round-trip back out in a
+// response.
+type registerRequest struct {
+	Name     string
+	Email    string
+	Password string
+}
+
+// RegisterUser handles user registration.
+func (api *MusicListerAPI) RegisterUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body registerRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if body.Name == "" || body.Email == "" || body.Password == "" {
+		http.Error(w, "Name, Email and Password are required", http.StatusBadRequest)
+		return
+	}
+
+	if _, exists, err := api.Store.Users().GetByEmail(ctx, body.Email); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if exists {
+		http.Error(w, "User with this email already exists", http.StatusBadRequest)
+		return
+	}
+
+	passwordHash, err := hashPassword(body.Password)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newUser := User{
+		ID:           generateUniqueID(),
+		SecretCode:   generateUniqueID(),
+		Name:         body.Name,
+		Email:        body.Email,
+		PasswordHash: passwordHash,
+	}
+	if err := api.Store.Users().Save(ctx, newUser); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newUser)
+}
+
+// ViewProfile handles viewing the authenticated caller's profile.
+func (api *MusicListerAPI) ViewProfile(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(userFromContext(r))
+}
+
+// GetAllSongsOfPlaylist handles getting all songs of a playlist. It returns
+// JSON by default, but negotiates an M3U/M3U8 or XSPF playlist file when the
+// client's Accept header asks for one, so media players can import playlists
+// directly.
+func (api *MusicListerAPI) GetAllSongsOfPlaylist(w http.ResponseWriter, r *http.Request) {
+	playlistID := r.URL.Query().Get("playlistId")
+
+	playlist, exists, err := api.Store.Playlists().Get(r.Context(), playlistID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Playlist not found", http.StatusNotFound)
+		return
+	}
+
+	switch accept := r.Header.Get("Accept"); {
+	case strings.Contains(accept, mimeM3U8), strings.Contains(accept, mimeM3U8Apple):
+		writeM3U8(w, playlist)
+	case strings.Contains(accept, mimeXSPF):
+		writeXSPF(w, playlist)
+	default:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(playlist.Songs)
+	}
+}
+
+// CreatePlaylist handles creating a new playlist.
+func (api *MusicListerAPI) CreatePlaylist(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := userFromContext(r)
+
+	var newPlaylist Playlist
+	err := json.NewDecoder(r.Body).Decode(&newPlaylist)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	newPlaylist.ID = generateUniqueID()
+	newPlaylist.UserID = user.ID
+	if err := api.Store.Playlists().Save(ctx, newPlaylist); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newPlaylist)
+}
+
+// DeletePlaylist handles deleting a playlist. Only the owner may delete it.
+func (api *MusicListerAPI) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	playlistID := r.URL.Query().Get("playlistId")
+	caller := userFromContext(r)
+
+	playlist, exists, err := api.Store.Playlists().Get(ctx, playlistID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Playlist not found", http.StatusNotFound)
+		return
+	}
+
+	if role, _ := roleOf(playlist, caller.ID); role != RoleOwner {
+		http.Error(w, "Only the playlist owner can delete it", http.StatusForbidden)
+		return
+	}
+
+	if err := api.Store.Playlists().Delete(ctx, playlistID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	api.Hub.broadcastPlaylistRefresh(playlistID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSongDetail handles getting details of a song.
+func (api *MusicListerAPI) GetSongDetail(w http.ResponseWriter, r *http.Request) {
+	songID := r.URL.Query().Get("songId")
+
+	song, exists, err := api.Store.Songs().Get(r.Context(), songID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(song)
+	} else {
+		http.Error(w, "Song not found", http.StatusNotFound)
+	}
+}
+
+// addSongToPlaylist handles adding a song to a playlist. The caller must be
+// the owner or an editor collaborator.
+func (api *MusicListerAPI) addSongToPlaylist(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	playlistID := r.URL.Query().Get("playlistId")
+	caller := userFromContext(r)
+
+	playlist, exists, err := api.Store.Playlists().Get(ctx, playlistID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Playlist not found", http.StatusNotFound)
+		return
+	}
+
+	if role, _ := roleOf(playlist, caller.ID); role != RoleOwner && role != RoleEditor {
+		http.Error(w, "Only the owner or an editor can add songs", http.StatusForbidden)
+		return
+	}
+
+	var newSong Song
+	err = json.NewDecoder(r.Body).Decode(&newSong)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	newSong.ID = generateUniqueID()
+	if err := api.Store.Songs().Save(ctx, newSong); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	playlist.Songs = append(playlist.Songs, newSong)
+	if err := api.Store.Playlists().Save(ctx, playlist); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	api.Hub.broadcastPlaylistRefresh(playlistID)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(playlist)
+}
+
+// generateUniqueID generates a unique ID using the UUID library.
+func generateUniqueID() string {
+	id := uuid.New()
+	return id.String()
+}
+
+// newDefaultDataStore builds the DataStore for the running process: a
+// SQLite-backed store when MUSICLISTER_DB_PATH is set, otherwise the
+// in-memory store used by tests.
+func newDefaultDataStore() (DataStore, error) {
+	if path := os.Getenv("MUSICLISTER_DB_PATH"); path != "" {
+		return NewSQLiteDataStore(path)
+	}
+	return NewMemoryDataStore(), nil
+}
+
+func main() {
+	if os.Getenv("MUSICLISTER_JWT_SECRET") == "" {
+		fmt.Println("MUSICLISTER_JWT_SECRET must be set; refusing to start")
+		return
+	}
+
+	store, err := newDefaultDataStore()
+	if err != nil {
+		fmt.Println("failed to initialise data store:", err)
+		return
+	}
+	api := NewMusicListerAPI(store)
+
+	http.HandleFunc("/register", api.RegisterUser)
+	http.HandleFunc("/login", api.Login)
+	http.HandleFunc("/refresh", api.RefreshToken)
+	http.HandleFunc("/ViewProfile", api.RequireAuth(api.ViewProfile))
+	http.HandleFunc("/getAllSongsOfPlaylist", api.GetAllSongsOfPlaylist)
+	http.HandleFunc("/createPlaylist", api.RequireAuth(api.CreatePlaylist))
+	http.HandleFunc("/deletePlaylist", api.RequireAuth(api.DeletePlaylist))
+	http.HandleFunc("/getSongDetail", api.GetSongDetail)
+	http.HandleFunc("/addSongToPlaylist", api.RequireAuth(api.addSongToPlaylist))
+
+	registerSubsonicRoutes(api)
+
+	http.HandleFunc("/import/spotify/login", api.RequireAuth(api.SpotifyLogin))
+	http.HandleFunc("/import/spotify/callback", api.SpotifyCallback)
+	http.HandleFunc("/import/spotify", api.RequireAuth(api.ImportSpotifyPlaylist))
+	http.HandleFunc("/import/bandcamp", api.RequireAuth(api.ImportBandcampAlbum))
+
+	http.HandleFunc("/playlist/acl", api.RequireAuth(api.PlaylistACL))
+	http.HandleFunc("/ws/playlist", api.RequireAuth(api.PlaylistWebSocket))
+
+	fmt.Println("Server is running on :8080")
+	http.ListenAndServe(":8080", nil)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	mimeM3U8      = "audio/x-mpegurl"
+	mimeM3U8Apple = "application/vnd.apple.mpegurl"
+	mimeXSPF      = "application/xspf+xml"
+)
+
+// sanitizeFilename strips characters that would let name break out of the
+// quoted filename parameter of a Content-Disposition header.
+func sanitizeFilename(name string) string {
+	replacer := strings.NewReplacer(`"`, "", "\\", "", "\r", "", "\n", "")
+	return replacer.Replace(name)
+}
+
+// writeM3U8 renders playlist as an extended M3U/M3U8 file, one #EXTINF/URL
+// pair per song, so it can be imported directly by media players.
+func writeM3U8(w http.ResponseWriter, playlist Playlist) {
+	w.Header().Set("Content-Type", mimeM3U8)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.m3u8"`, sanitizeFilename(playlist.Name)))
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	for _, song := range playlist.Songs {
+		fmt.Fprintf(&b, "#EXTINF:-1,%s - %s\n%s\n", song.Composers, song.Name, song.MusicURL)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(b.String()))
+}
+
+// xspfPlaylist mirrors the subset of the XSPF schema MusicLister emits.
+type xspfPlaylist struct {
+	XMLName   xml.Name      `xml:"playlist"`
+	Version   string        `xml:"version,attr"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Title     string        `xml:"title"`
+	TrackList xspfTrackList `xml:"trackList"`
+}
+
+type xspfTrackList struct {
+	Track []xspfTrack `xml:"track"`
+}
+
+type xspfTrack struct {
+	Title    string `xml:"title"`
+	Creator  string `xml:"creator"`
+	Location string `xml:"location"`
+}
+
+// writeXSPF renders playlist as an XSPF document.
+func writeXSPF(w http.ResponseWriter, playlist Playlist) {
+	tracks := make([]xspfTrack, 0, len(playlist.Songs))
+	for _, song := range playlist.Songs {
+		tracks = append(tracks, xspfTrack{Title: song.Name, Creator: song.Composers, Location: song.MusicURL})
+	}
+
+	doc := xspfPlaylist{
+		Version:   "1",
+		Xmlns:     "http://xspf.org/ns/0/",
+		Title:     playlist.Name,
+		TrackList: xspfTrackList{Track: tracks},
+	}
+
+	w.Header().Set("Content-Type", mimeXSPF)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xspf"`, sanitizeFilename(playlist.Name)))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(doc)
+}
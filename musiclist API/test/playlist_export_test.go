@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteM3U8(t *testing.T) {
+	playlist := Playlist{
+		Name: "Favorites",
+		Songs: []Song{
+			{Name: "Clair de Lune", Composers: "Debussy", MusicURL: "https://example.com/clair-de-lune.mp3"},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	writeM3U8(rec, playlist)
+
+	if ct := rec.Header().Get("Content-Type"); ct != mimeM3U8 {
+		t.Fatalf("Content-Type = %q, want %q", ct, mimeM3U8)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="Favorites.m3u8"` {
+		t.Fatalf("Content-Disposition = %q", cd)
+	}
+
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "#EXTM3U\n") {
+		t.Fatalf("body = %q, want it to start with #EXTM3U", body)
+	}
+	if !strings.Contains(body, "#EXTINF:-1,Debussy - Clair de Lune\n") {
+		t.Fatalf("body = %q, missing expected #EXTINF line", body)
+	}
+	if !strings.Contains(body, "https://example.com/clair-de-lune.mp3\n") {
+		t.Fatalf("body = %q, missing expected song URL", body)
+	}
+}
+
+func TestWriteM3U8SanitizesFilename(t *testing.T) {
+	playlist := Playlist{Name: `evil" filename`}
+
+	rec := httptest.NewRecorder()
+	writeM3U8(rec, playlist)
+
+	cd := rec.Header().Get("Content-Disposition")
+	if strings.Count(cd, `"`) != 2 {
+		t.Fatalf("Content-Disposition = %q, want exactly the two quotes bracketing the filename", cd)
+	}
+}
+
+func TestWriteXSPF(t *testing.T) {
+	playlist := Playlist{
+		Name: "Favorites",
+		Songs: []Song{
+			{Name: "Clair de Lune", Composers: "Debussy", MusicURL: "https://example.com/clair-de-lune.mp3"},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	writeXSPF(rec, playlist)
+
+	if ct := rec.Header().Get("Content-Type"); ct != mimeXSPF {
+		t.Fatalf("Content-Type = %q, want %q", ct, mimeXSPF)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="Favorites.xspf"` {
+		t.Fatalf("Content-Disposition = %q", cd)
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "<title>Favorites</title>") {
+		t.Fatalf("body = %q, missing playlist title", body)
+	}
+	if !strings.Contains(body, "<title>Clair de Lune</title>") || !strings.Contains(body, "<creator>Debussy</creator>") {
+		t.Fatalf("body = %q, missing track entry", body)
+	}
+}
+
+func TestWriteXSPFSanitizesFilename(t *testing.T) {
+	playlist := Playlist{Name: `evil" filename`}
+
+	rec := httptest.NewRecorder()
+	writeXSPF(rec, playlist)
+
+	cd := rec.Header().Get("Content-Disposition")
+	if strings.Count(cd, `"`) != 2 {
+		t.Fatalf("Content-Disposition = %q, want exactly the two quotes bracketing the filename", cd)
+	}
+}
+
+func TestGetAllSongsOfPlaylistNegotiatesAccept(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+
+	playlist := Playlist{
+		ID:   "playlist-1",
+		Name: "Favorites",
+		Songs: []Song{
+			{ID: "song-1", Name: "Clair de Lune", Composers: "Debussy", MusicURL: "https://example.com/clair-de-lune.mp3"},
+		},
+	}
+	mustSavePlaylist(t, store, playlist)
+
+	for _, tc := range []struct {
+		accept     string
+		wantType   string
+		wantInBody string
+	}{
+		{"", "", `"ID":"song-1"`},
+		{mimeM3U8, mimeM3U8, "#EXTM3U"},
+		{mimeM3U8Apple, mimeM3U8, "#EXTM3U"},
+		{mimeXSPF, mimeXSPF, "<trackList>"},
+	} {
+		req := httptest.NewRequest("GET", "/getAllSongsOfPlaylist?playlistId=playlist-1", nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		rec := httptest.NewRecorder()
+		api.GetAllSongsOfPlaylist(rec, req)
+
+		if !strings.Contains(rec.Body.String(), tc.wantInBody) {
+			t.Fatalf("Accept=%q body = %q, want it to contain %q", tc.accept, rec.Body.String(), tc.wantInBody)
+		}
+		if tc.wantType != "" {
+			if ct := rec.Header().Get("Content-Type"); ct != tc.wantType {
+				t.Fatalf("Accept=%q Content-Type = %q, want %q", tc.accept, ct, tc.wantType)
+			}
+		}
+	}
+}
+
+func mustSavePlaylist(t *testing.T, store DataStore, playlist Playlist) {
+	t.Helper()
+	if err := store.Playlists().Save(context.Background(), playlist); err != nil {
+		t.Fatalf("Save playlist: %v", err)
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spotifyAuthURL  = "https://accounts.spotify.com/authorize"
+	spotifyTokenURL = "https://accounts.spotify.com/api/token"
+	spotifyAPIURL   = "https://api.spotify.com/v1"
+
+	// spotifyStateTTL bounds how long an unused authorization request stays
+	// valid, so an intercepted callback URL can't be replayed indefinitely.
+	spotifyStateTTL = 10 * time.Minute
+)
+
+// spotifyOAuthStates binds an in-flight Spotify authorization request to the
+// user who started it, so the callback can recover the initiating user
+// without trusting the client-supplied state parameter directly: state is
+// only ever an opaque, unguessable lookup key into this table.
+type spotifyOAuthStates struct {
+	mutex   sync.Mutex
+	byNonce map[string]spotifyOAuthState
+}
+
+type spotifyOAuthState struct {
+	userID   string
+	issuedAt time.Time
+}
+
+// newSpotifyOAuthStates creates an empty state table.
+func newSpotifyOAuthStates() *spotifyOAuthStates {
+	return &spotifyOAuthStates{byNonce: make(map[string]spotifyOAuthState)}
+}
+
+// begin generates an unguessable nonce for userID and records it, returning
+// the nonce to use as the OAuth state parameter.
+func (s *spotifyOAuthStates) begin(userID string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	nonce := hex.EncodeToString(buf)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.byNonce[nonce] = spotifyOAuthState{userID: userID, issuedAt: time.Now()}
+	return nonce, nil
+}
+
+// resolve consumes nonce (it is single-use) and returns the user ID that
+// started the flow, or false if the nonce is unknown or has expired.
+func (s *spotifyOAuthStates) resolve(nonce string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.byNonce[nonce]
+	delete(s.byNonce, nonce)
+	if !ok || time.Since(state.issuedAt) > spotifyStateTTL {
+		return "", false
+	}
+	return state.userID, true
+}
+
+// spotifyOAuthConfig reads the client credentials and redirect URI the
+// Spotify authorization-code flow needs from the environment.
+func spotifyOAuthConfig() (clientID, clientSecret, redirectURI string, ok bool) {
+	clientID = os.Getenv("SPOTIFY_CLIENT_ID")
+	clientSecret = os.Getenv("SPOTIFY_CLIENT_SECRET")
+	redirectURI = os.Getenv("SPOTIFY_REDIRECT_URI")
+	return clientID, clientSecret, redirectURI, clientID != "" && clientSecret != "" && redirectURI != ""
+}
+
+// SpotifyLogin handles GET /import/spotify/login, redirecting the user to
+// Spotify's consent screen. A server-side nonce bound to the caller is
+// threaded through as the OAuth state parameter, so the callback can recover
+// which user to attach the token to without trusting a client-supplied ID.
+func (api *MusicListerAPI) SpotifyLogin(w http.ResponseWriter, r *http.Request) {
+	clientID, _, redirectURI, ok := spotifyOAuthConfig()
+	if !ok {
+		http.Error(w, "Spotify integration is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	user := userFromContext(r)
+
+	state, err := api.SpotifyStates.begin(user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {clientID},
+		"redirect_uri":  {redirectURI},
+		"scope":         {"playlist-read-private"},
+		"state":         {state},
+	}
+	http.Redirect(w, r, spotifyAuthURL+"?"+query.Encode(), http.StatusFound)
+}
+
+type spotifyTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// exchangeSpotifyCode trades an authorization code (or a refresh token, when
+// grantType is "refresh_token") for an access token.
+func exchangeSpotifyCode(grantType, codeOrRefreshToken, redirectURI string) (*spotifyTokenResponse, error) {
+	clientID, clientSecret, _, ok := spotifyOAuthConfig()
+	if !ok {
+		return nil, fmt.Errorf("spotify integration is not configured")
+	}
+
+	form := url.Values{"grant_type": {grantType}}
+	if grantType == "refresh_token" {
+		form.Set("refresh_token", codeOrRefreshToken)
+	} else {
+		form.Set("code", codeOrRefreshToken)
+		form.Set("redirect_uri", redirectURI)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, spotifyTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("spotify token request failed: %s", resp.Status)
+	}
+
+	var token spotifyTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// SpotifyCallback handles GET /import/spotify/callback, exchanging the
+// authorization code for an access/refresh token pair and attaching it to
+// the user who started the flow, as resolved from the state nonce SpotifyLogin
+// recorded (the state parameter itself is never trusted as a user ID).
+func (api *MusicListerAPI) SpotifyCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	code := r.URL.Query().Get("code")
+
+	userID, ok := api.SpotifyStates.resolve(r.URL.Query().Get("state"))
+	if !ok {
+		http.Error(w, "Invalid or expired state", http.StatusBadRequest)
+		return
+	}
+
+	_, _, redirectURI, ok := spotifyOAuthConfig()
+	if !ok {
+		http.Error(w, "Spotify integration is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	user, exists, err := api.Store.Users().Get(ctx, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	token, err := exchangeSpotifyCode("authorization_code", code, redirectURI)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	user.SpotifyToken = &SpotifyToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}
+	if err := api.Store.Users().Save(ctx, user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "Spotify account linked, you can close this window.")
+}
+
+// validSpotifyToken returns a usable access token for user, transparently
+// refreshing it via the stored refresh token when it has expired.
+func (api *MusicListerAPI) validSpotifyToken(r *http.Request, user *User) (string, error) {
+	if user.SpotifyToken == nil {
+		return "", fmt.Errorf("user has not linked a Spotify account")
+	}
+	if time.Now().Before(user.SpotifyToken.Expiry) {
+		return user.SpotifyToken.AccessToken, nil
+	}
+
+	token, err := exchangeSpotifyCode("refresh_token", user.SpotifyToken.RefreshToken, "")
+	if err != nil {
+		return "", err
+	}
+
+	refreshToken := token.RefreshToken
+	if refreshToken == "" {
+		refreshToken = user.SpotifyToken.RefreshToken
+	}
+	user.SpotifyToken = &SpotifyToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: refreshToken,
+		Expiry:       time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}
+	if err := api.Store.Users().Save(r.Context(), *user); err != nil {
+		return "", err
+	}
+	return user.SpotifyToken.AccessToken, nil
+}
+
+type spotifyTracksPage struct {
+	Next  string `json:"next"`
+	Items []struct {
+		Track struct {
+			Name    string `json:"name"`
+			Artists []struct {
+				Name string `json:"name"`
+			} `json:"artists"`
+			ExternalURLs struct {
+				Spotify string `json:"spotify"`
+			} `json:"external_urls"`
+		} `json:"track"`
+	} `json:"items"`
+}
+
+// fetchSpotifyPlaylistTracks walks every page of a playlist's tracks,
+// following the `next` URL returned by the API until it is null.
+func fetchSpotifyPlaylistTracks(accessToken, playlistID string) ([]Song, error) {
+	var songs []Song
+	next := spotifyAPIURL + "/playlists/" + playlistID + "/tracks?offset=0"
+
+	for next != "" {
+		req, err := http.NewRequest(http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("spotify tracks request failed: %s", resp.Status)
+		}
+
+		var page spotifyTracksPage
+		decodeErr := json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		for _, item := range page.Items {
+			artists := make([]string, 0, len(item.Track.Artists))
+			for _, artist := range item.Track.Artists {
+				artists = append(artists, artist.Name)
+			}
+			songs = append(songs, Song{
+				Name:      item.Track.Name,
+				Composers: strings.Join(artists, ", "),
+				MusicURL:  item.Track.ExternalURLs.Spotify,
+			})
+		}
+
+		next = page.Next
+	}
+
+	return songs, nil
+}
+
+// spotifyPlaylistID extracts the playlist ID from an open.spotify.com
+// playlist URL, e.g. https://open.spotify.com/playlist/{id}?si=....
+func spotifyPlaylistID(playlistURL string) (string, error) {
+	parsed, err := url.Parse(playlistURL)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for i, part := range parts {
+		if part == "playlist" && i+1 < len(parts) {
+			return parts[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("could not find a playlist ID in %q", playlistURL)
+}
+
+// ImportSpotifyPlaylist handles POST /import/spotify, creating a MusicLister
+// playlist from a Spotify playlist URL using the user's linked account.
+func (api *MusicListerAPI) ImportSpotifyPlaylist(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	playlistURL := r.URL.Query().Get("playlistUrl")
+	user := userFromContext(r)
+
+	accessToken, err := api.validSpotifyToken(r, user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	playlistID, err := spotifyPlaylistID(playlistURL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	songs, err := fetchSpotifyPlaylistTracks(accessToken, playlistID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	for i := range songs {
+		songs[i].ID = generateUniqueID()
+		if err := api.Store.Songs().Save(ctx, songs[i]); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	playlist := Playlist{
+		ID:     generateUniqueID(),
+		Name:   "Spotify import " + strconv.FormatInt(int64(len(songs)), 10) + " songs",
+		Songs:  songs,
+		UserID: user.ID,
+	}
+	if err := api.Store.Playlists().Save(ctx, playlist); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(playlist)
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestSpotifyOAuthStatesResolveIsSingleUse(t *testing.T) {
+	states := newSpotifyOAuthStates()
+
+	nonce, err := states.begin("user-1")
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+
+	userID, ok := states.resolve(nonce)
+	if !ok || userID != "user-1" {
+		t.Fatalf("resolve(%q) = (%q, %v), want (%q, true)", nonce, userID, ok, "user-1")
+	}
+
+	if _, ok := states.resolve(nonce); ok {
+		t.Fatal("expected a nonce to be unusable after it has already been resolved")
+	}
+}
+
+func TestSpotifyOAuthStatesRejectsUnknownOrForgedState(t *testing.T) {
+	states := newSpotifyOAuthStates()
+
+	if _, ok := states.resolve("victim-user-id"); ok {
+		t.Fatal("expected resolving an arbitrary, never-issued state to fail")
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteMigrations creates the schema on a fresh database. It is safe to run
+// against an already-migrated database since every statement is idempotent.
+var sqliteMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		secret_code TEXT UNIQUE NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL DEFAULT '',
+		spotify_access_token TEXT,
+		spotify_refresh_token TEXT,
+		spotify_token_expiry DATETIME
+	)`,
+	`CREATE TABLE IF NOT EXISTS playlists (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		user_id TEXT NOT NULL REFERENCES users(id),
+		collaborators TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS songs (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		composers TEXT,
+		music_url TEXT,
+		playlist_id TEXT REFERENCES playlists(id)
+	)`,
+}
+
+// sqliteDataStore is a DataStore backed by a SQLite database, so data
+// survives process restarts instead of living only in memory.
+type sqliteDataStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteDataStore opens (creating if necessary) the SQLite database at
+// path and applies any pending migrations.
+func NewSQLiteDataStore(path string) (DataStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range sqliteMigrations {
+		if _, err := db.Exec(migration); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return &sqliteDataStore{db: db}, nil
+}
+
+func (s *sqliteDataStore) Users() UserStore         { return &sqliteUserStore{db: s.db} }
+func (s *sqliteDataStore) Playlists() PlaylistStore { return &sqlitePlaylistStore{db: s.db} }
+func (s *sqliteDataStore) Songs() SongStore         { return &sqliteSongStore{db: s.db} }
+
+type sqliteUserStore struct{ db *sql.DB }
+
+const userColumns = `id, secret_code, name, email, password_hash, spotify_access_token, spotify_refresh_token, spotify_token_expiry`
+
+func (s *sqliteUserStore) scanUser(row *sql.Row) (User, bool, error) {
+	var user User
+	var accessToken, refreshToken sql.NullString
+	var expiry sql.NullTime
+	if err := row.Scan(&user.ID, &user.SecretCode, &user.Name, &user.Email, &user.PasswordHash, &accessToken, &refreshToken, &expiry); err != nil {
+		if err == sql.ErrNoRows {
+			return User{}, false, nil
+		}
+		return User{}, false, err
+	}
+	if accessToken.Valid {
+		user.SpotifyToken = &SpotifyToken{
+			AccessToken:  accessToken.String,
+			RefreshToken: refreshToken.String,
+			Expiry:       expiry.Time,
+		}
+	}
+	return user, true, nil
+}
+
+func (s *sqliteUserStore) Get(ctx context.Context, id string) (User, bool, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+	return s.scanUser(row)
+}
+
+func (s *sqliteUserStore) GetByEmail(ctx context.Context, email string) (User, bool, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = ?`, email)
+	return s.scanUser(row)
+}
+
+func (s *sqliteUserStore) GetByName(ctx context.Context, name string) (User, bool, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE name = ?`, name)
+	return s.scanUser(row)
+}
+
+func (s *sqliteUserStore) Save(ctx context.Context, user User) error {
+	var accessToken, refreshToken sql.NullString
+	var expiry sql.NullTime
+	if user.SpotifyToken != nil {
+		accessToken = sql.NullString{String: user.SpotifyToken.AccessToken, Valid: true}
+		refreshToken = sql.NullString{String: user.SpotifyToken.RefreshToken, Valid: true}
+		expiry = sql.NullTime{Time: user.SpotifyToken.Expiry, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, secret_code, name, email, password_hash, spotify_access_token, spotify_refresh_token, spotify_token_expiry)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			secret_code = excluded.secret_code,
+			name = excluded.name,
+			email = excluded.email,
+			password_hash = excluded.password_hash,
+			spotify_access_token = excluded.spotify_access_token,
+			spotify_refresh_token = excluded.spotify_refresh_token,
+			spotify_token_expiry = excluded.spotify_token_expiry
+	`, user.ID, user.SecretCode, user.Name, user.Email, user.PasswordHash, accessToken, refreshToken, expiry)
+	return err
+}
+
+type sqlitePlaylistStore struct{ db *sql.DB }
+
+func (s *sqlitePlaylistStore) songsFor(ctx context.Context, playlistID string) ([]Song, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name, composers, music_url FROM songs WHERE playlist_id = ?`, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var songs []Song
+	for rows.Next() {
+		var song Song
+		if err := rows.Scan(&song.ID, &song.Name, &song.Composers, &song.MusicURL); err != nil {
+			return nil, err
+		}
+		songs = append(songs, song)
+	}
+	return songs, rows.Err()
+}
+
+// scanCollaborators decodes the JSON-encoded collaborators column, treating
+// NULL/empty as "no collaborators".
+func scanCollaborators(raw sql.NullString) (map[string]Role, error) {
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+	var collaborators map[string]Role
+	if err := json.Unmarshal([]byte(raw.String), &collaborators); err != nil {
+		return nil, err
+	}
+	return collaborators, nil
+}
+
+func (s *sqlitePlaylistStore) Get(ctx context.Context, id string) (Playlist, bool, error) {
+	var playlist Playlist
+	var collaborators sql.NullString
+	row := s.db.QueryRowContext(ctx, `SELECT id, name, user_id, collaborators FROM playlists WHERE id = ?`, id)
+	if err := row.Scan(&playlist.ID, &playlist.Name, &playlist.UserID, &collaborators); err != nil {
+		if err == sql.ErrNoRows {
+			return Playlist{}, false, nil
+		}
+		return Playlist{}, false, err
+	}
+
+	roles, err := scanCollaborators(collaborators)
+	if err != nil {
+		return Playlist{}, false, err
+	}
+	playlist.Collaborators = roles
+
+	songs, err := s.songsFor(ctx, playlist.ID)
+	if err != nil {
+		return Playlist{}, false, err
+	}
+	playlist.Songs = songs
+	return playlist, true, nil
+}
+
+func (s *sqlitePlaylistStore) List(ctx context.Context) ([]Playlist, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name, user_id, collaborators FROM playlists`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var playlists []Playlist
+	for rows.Next() {
+		var playlist Playlist
+		var collaborators sql.NullString
+		if err := rows.Scan(&playlist.ID, &playlist.Name, &playlist.UserID, &collaborators); err != nil {
+			return nil, err
+		}
+		roles, err := scanCollaborators(collaborators)
+		if err != nil {
+			return nil, err
+		}
+		playlist.Collaborators = roles
+
+		songs, err := s.songsFor(ctx, playlist.ID)
+		if err != nil {
+			return nil, err
+		}
+		playlist.Songs = songs
+		playlists = append(playlists, playlist)
+	}
+	return playlists, rows.Err()
+}
+
+func (s *sqlitePlaylistStore) Save(ctx context.Context, playlist Playlist) error {
+	var collaborators sql.NullString
+	if len(playlist.Collaborators) > 0 {
+		encoded, err := json.Marshal(playlist.Collaborators)
+		if err != nil {
+			return err
+		}
+		collaborators = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO playlists (id, name, user_id, collaborators) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, user_id = excluded.user_id, collaborators = excluded.collaborators
+	`, playlist.ID, playlist.Name, playlist.UserID, collaborators)
+	if err != nil {
+		return err
+	}
+
+	for _, song := range playlist.Songs {
+		if _, err := s.db.ExecContext(ctx, `
+			INSERT INTO songs (id, name, composers, music_url, playlist_id) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				name = excluded.name,
+				composers = excluded.composers,
+				music_url = excluded.music_url,
+				playlist_id = excluded.playlist_id
+		`, song.ID, song.Name, song.Composers, song.MusicURL, playlist.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlitePlaylistStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM playlists WHERE id = ?`, id)
+	return err
+}
+
+type sqliteSongStore struct{ db *sql.DB }
+
+func (s *sqliteSongStore) Get(ctx context.Context, id string) (Song, bool, error) {
+	var song Song
+	row := s.db.QueryRowContext(ctx, `SELECT id, name, composers, music_url FROM songs WHERE id = ?`, id)
+	if err := row.Scan(&song.ID, &song.Name, &song.Composers, &song.MusicURL); err != nil {
+		if err == sql.ErrNoRows {
+			return Song{}, false, nil
+		}
+		return Song{}, false, err
+	}
+	return song, true, nil
+}
+
+func (s *sqliteSongStore) Save(ctx context.Context, song Song) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO songs (id, name, composers, music_url) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, composers = excluded.composers, music_url = excluded.music_url
+	`, song.ID, song.Name, song.Composers, song.MusicURL)
+	return err
+}
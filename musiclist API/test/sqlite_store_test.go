@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func newTestSQLiteStore(t *testing.T) DataStore {
+	t.Helper()
+	store, err := NewSQLiteDataStore(filepath.Join(t.TempDir(), "musiclist.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteDataStore: %v", err)
+	}
+	return store
+}
+
+func TestSQLiteUserStorePersistsPasswordHash(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	user := User{ID: "user-1", SecretCode: "secret-1", Name: "Ada", Email: "ada@example.com", PasswordHash: "bcrypt-hash"}
+	if err := store.Users().Save(ctx, user); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, exists, err := store.Users().GetByEmail(ctx, "ada@example.com")
+	if err != nil || !exists {
+		t.Fatalf("GetByEmail = (%+v, %v, %v)", got, exists, err)
+	}
+	if got.PasswordHash != "bcrypt-hash" {
+		t.Fatalf("PasswordHash = %q, want %q", got.PasswordHash, "bcrypt-hash")
+	}
+}
+
+func TestSQLitePlaylistStoreRoundTripsCollaborators(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	playlist := Playlist{
+		ID:            "playlist-1",
+		Name:          "Favorites",
+		UserID:        "user-1",
+		Collaborators: map[string]Role{"user-2": RoleEditor},
+	}
+	if err := store.Playlists().Save(ctx, playlist); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, exists, err := store.Playlists().Get(ctx, "playlist-1")
+	if err != nil || !exists {
+		t.Fatalf("Get = (%+v, %v, %v)", got, exists, err)
+	}
+	if got.Collaborators["user-2"] != RoleEditor {
+		t.Fatalf("Collaborators[user-2] = %q, want %q", got.Collaborators["user-2"], RoleEditor)
+	}
+
+	if err := store.Playlists().Delete(ctx, "playlist-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, exists, err := store.Playlists().Get(ctx, "playlist-1"); err != nil || exists {
+		t.Fatalf("Get after delete = (_, %v, %v), want (_, false, nil)", exists, err)
+	}
+}
+
+func TestSQLitePlaylistStoreSavesEmbeddedSongs(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	// A playlist saved with an embedded song that was never separately
+	// passed through SongStore.Save (e.g. decoded straight from a request
+	// body) must still come back with that song attached.
+	playlist := Playlist{
+		ID:     "playlist-1",
+		Name:   "Favorites",
+		UserID: "user-1",
+		Songs:  []Song{{ID: "song-1", Name: "Clair de Lune", Composers: "Debussy"}},
+	}
+	if err := store.Playlists().Save(ctx, playlist); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, exists, err := store.Playlists().Get(ctx, "playlist-1")
+	if err != nil || !exists {
+		t.Fatalf("Get = (%+v, %v, %v)", got, exists, err)
+	}
+	if len(got.Songs) != 1 || got.Songs[0].ID != "song-1" {
+		t.Fatalf("Songs = %+v, want one song with ID song-1", got.Songs)
+	}
+}
+
+func TestSQLiteSongStoreAttachesToPlaylist(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	playlist := Playlist{ID: "playlist-1", Name: "Favorites", UserID: "user-1"}
+	if err := store.Playlists().Save(ctx, playlist); err != nil {
+		t.Fatalf("Save playlist: %v", err)
+	}
+
+	song := Song{ID: "song-1", Name: "Clair de Lune", Composers: "Debussy"}
+	if err := store.Songs().Save(ctx, song); err != nil {
+		t.Fatalf("Save song: %v", err)
+	}
+
+	playlist.Songs = []Song{song}
+	if err := store.Playlists().Save(ctx, playlist); err != nil {
+		t.Fatalf("Save playlist with song: %v", err)
+	}
+
+	got, exists, err := store.Playlists().Get(ctx, "playlist-1")
+	if err != nil || !exists || len(got.Songs) != 1 || got.Songs[0].ID != "song-1" {
+		t.Fatalf("Get = (%+v, %v, %v), want one song", got, exists, err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "context"
+
+// UserStore is the repository for Users.
+type UserStore interface {
+	Get(ctx context.Context, id string) (User, bool, error)
+	GetByEmail(ctx context.Context, email string) (User, bool, error)
+	GetByName(ctx context.Context, name string) (User, bool, error)
+	Save(ctx context.Context, user User) error
+}
+
+// PlaylistStore is the repository for Playlists.
+type PlaylistStore interface {
+	Get(ctx context.Context, id string) (Playlist, bool, error)
+	List(ctx context.Context) ([]Playlist, error)
+	Save(ctx context.Context, playlist Playlist) error
+	Delete(ctx context.Context, id string) error
+}
+
+// SongStore is the repository for Songs.
+type SongStore interface {
+	Get(ctx context.Context, id string) (Song, bool, error)
+	Save(ctx context.Context, song Song) error
+}
+
+// DataStore groups the repositories MusicListerAPI depends on, one accessor
+// per aggregate, mirroring the repository pattern used by Navidrome. This
+// lets MusicListerAPI stay storage-agnostic: callers can hand it an
+// in-memory store for tests or a persistent one for production.
+type DataStore interface {
+	Users() UserStore
+	Playlists() PlaylistStore
+	Songs() SongStore
+}
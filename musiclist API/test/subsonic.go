@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+)
+
+// subsonicVersion is the Subsonic API version this surface emulates.
+const subsonicVersion = "1.16.1"
+
+// subsonicEnvelope is the common wrapper every Subsonic response is nested in.
+type subsonicEnvelope struct {
+	XMLName xml.Name `xml:"subsonic-response" json:"-"`
+	Status  string   `xml:"status,attr" json:"status"`
+	Version string   `xml:"version,attr" json:"version"`
+
+	Error     *subsonicError     `xml:"error,omitempty" json:"error,omitempty"`
+	Playlists *subsonicPlaylists `xml:"playlists,omitempty" json:"playlists,omitempty"`
+	Playlist  *subsonicPlaylist  `xml:"playlist,omitempty" json:"playlist,omitempty"`
+	Song      *subsonicSong      `xml:"song,omitempty" json:"song,omitempty"`
+}
+
+type subsonicError struct {
+	Code    int    `xml:"code,attr" json:"code"`
+	Message string `xml:"message,attr" json:"message"`
+}
+
+type subsonicPlaylists struct {
+	Playlist []subsonicPlaylist `xml:"playlist" json:"playlist"`
+}
+
+type subsonicPlaylist struct {
+	ID        string         `xml:"id,attr" json:"id"`
+	Name      string         `xml:"name,attr" json:"name"`
+	SongCount int            `xml:"songCount,attr" json:"songCount"`
+	Entry     []subsonicSong `xml:"entry" json:"entry,omitempty"`
+}
+
+type subsonicSong struct {
+	ID          string `xml:"id,attr" json:"id"`
+	Title       string `xml:"title,attr" json:"title"`
+	Artist      string `xml:"artist,attr" json:"artist"`
+	ContentType string `xml:"contentType,attr,omitempty" json:"contentType,omitempty"`
+}
+
+// subsonicToken hashes a user's SecretCode with the client-supplied salt the
+// same way Subsonic clients derive t from a password: md5(password + salt).
+func subsonicToken(secretCode, salt string) string {
+	sum := md5.Sum([]byte(secretCode + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticateSubsonic resolves the user identified by the standard u/t/s
+// query parameters, verifying t against md5(SecretCode + s).
+func (api *MusicListerAPI) authenticateSubsonic(r *http.Request) (*User, bool) {
+	username := r.URL.Query().Get("u")
+	token := r.URL.Query().Get("t")
+	salt := r.URL.Query().Get("s")
+	if username == "" || token == "" || salt == "" {
+		return nil, false
+	}
+
+	user, exists, err := api.Store.Users().GetByName(r.Context(), username)
+	if err != nil || !exists {
+		return nil, false
+	}
+	if subsonicToken(user.SecretCode, salt) != token {
+		return nil, false
+	}
+	return &user, true
+}
+
+// writeSubsonicResponse serialises env as XML by default, or JSON when the
+// client passes f=json, matching how Subsonic clients negotiate format.
+func writeSubsonicResponse(w http.ResponseWriter, r *http.Request, env subsonicEnvelope) {
+	if r.URL.Query().Get("f") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			SubsonicResponse subsonicEnvelope `json:"subsonic-response"`
+		}{env})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(env)
+}
+
+func subsonicOK() subsonicEnvelope {
+	return subsonicEnvelope{Status: "ok", Version: subsonicVersion}
+}
+
+func subsonicFail(code int, message string) subsonicEnvelope {
+	env := subsonicEnvelope{Status: "failed", Version: subsonicVersion}
+	env.Error = &subsonicError{Code: code, Message: message}
+	return env
+}
+
+// Ping handles GET /rest/ping.view, the Subsonic connectivity check.
+func (api *MusicListerAPI) Ping(w http.ResponseWriter, r *http.Request) {
+	writeSubsonicResponse(w, r, subsonicOK())
+}
+
+// GetPlaylists handles GET /rest/getPlaylists.view, listing every playlist
+// owned by the authenticated user.
+func (api *MusicListerAPI) GetPlaylists(w http.ResponseWriter, r *http.Request) {
+	user, ok := api.authenticateSubsonic(r)
+	if !ok {
+		writeSubsonicResponse(w, r, subsonicFail(40, "Wrong username or password"))
+		return
+	}
+
+	all, err := api.Store.Playlists().List(r.Context())
+	if err != nil {
+		writeSubsonicResponse(w, r, subsonicFail(0, err.Error()))
+		return
+	}
+
+	var playlists []subsonicPlaylist
+	for _, playlist := range all {
+		if playlist.UserID != user.ID {
+			continue
+		}
+		playlists = append(playlists, subsonicPlaylist{
+			ID:        playlist.ID,
+			Name:      playlist.Name,
+			SongCount: len(playlist.Songs),
+		})
+	}
+
+	env := subsonicOK()
+	env.Playlists = &subsonicPlaylists{Playlist: playlists}
+	writeSubsonicResponse(w, r, env)
+}
+
+// GetPlaylist handles GET /rest/getPlaylist.view, returning a single
+// playlist with its full song entries.
+func (api *MusicListerAPI) GetPlaylist(w http.ResponseWriter, r *http.Request) {
+	user, ok := api.authenticateSubsonic(r)
+	if !ok {
+		writeSubsonicResponse(w, r, subsonicFail(40, "Wrong username or password"))
+		return
+	}
+
+	playlistID := r.URL.Query().Get("id")
+
+	playlist, exists, err := api.Store.Playlists().Get(r.Context(), playlistID)
+	if err != nil {
+		writeSubsonicResponse(w, r, subsonicFail(0, err.Error()))
+		return
+	}
+	if !exists {
+		writeSubsonicResponse(w, r, subsonicFail(70, "Playlist not found"))
+		return
+	}
+	if _, ok := roleOf(playlist, user.ID); !ok {
+		writeSubsonicResponse(w, r, subsonicFail(50, "Not authorized for this playlist"))
+		return
+	}
+
+	entries := make([]subsonicSong, 0, len(playlist.Songs))
+	for _, song := range playlist.Songs {
+		entries = append(entries, subsonicSong{ID: song.ID, Title: song.Name, Artist: song.Composers})
+	}
+
+	env := subsonicOK()
+	env.Playlist = &subsonicPlaylist{
+		ID:        playlist.ID,
+		Name:      playlist.Name,
+		SongCount: len(playlist.Songs),
+		Entry:     entries,
+	}
+	writeSubsonicResponse(w, r, env)
+}
+
+// CreatePlaylist handles GET /rest/createPlaylist.view, creating a new
+// playlist for the authenticated user.
+func (api *MusicListerAPI) CreateSubsonicPlaylist(w http.ResponseWriter, r *http.Request) {
+	user, ok := api.authenticateSubsonic(r)
+	if !ok {
+		writeSubsonicResponse(w, r, subsonicFail(40, "Wrong username or password"))
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeSubsonicResponse(w, r, subsonicFail(10, "Required parameter 'name' is missing"))
+		return
+	}
+
+	playlist := Playlist{
+		ID:     generateUniqueID(),
+		Name:   name,
+		UserID: user.ID,
+	}
+	if err := api.Store.Playlists().Save(r.Context(), playlist); err != nil {
+		writeSubsonicResponse(w, r, subsonicFail(0, err.Error()))
+		return
+	}
+
+	env := subsonicOK()
+	env.Playlist = &subsonicPlaylist{ID: playlist.ID, Name: playlist.Name}
+	writeSubsonicResponse(w, r, env)
+}
+
+// subsonicUserCanAccessSong reports whether user holds at least a viewer
+// role on the playlist containing songID. Song doesn't carry its own
+// playlist reference, so every playlist is searched for it.
+func (api *MusicListerAPI) subsonicUserCanAccessSong(ctx context.Context, user *User, songID string) (bool, error) {
+	playlists, err := api.Store.Playlists().List(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, playlist := range playlists {
+		for _, song := range playlist.Songs {
+			if song.ID == songID {
+				_, ok := roleOf(playlist, user.ID)
+				return ok, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// GetSong handles GET /rest/getSong.view, returning details of a single
+// song by ID.
+func (api *MusicListerAPI) GetSong(w http.ResponseWriter, r *http.Request) {
+	user, ok := api.authenticateSubsonic(r)
+	if !ok {
+		writeSubsonicResponse(w, r, subsonicFail(40, "Wrong username or password"))
+		return
+	}
+
+	songID := r.URL.Query().Get("id")
+
+	song, exists, err := api.Store.Songs().Get(r.Context(), songID)
+	if err != nil {
+		writeSubsonicResponse(w, r, subsonicFail(0, err.Error()))
+		return
+	}
+	if !exists {
+		writeSubsonicResponse(w, r, subsonicFail(70, "Song not found"))
+		return
+	}
+	if authorized, err := api.subsonicUserCanAccessSong(r.Context(), user, song.ID); err != nil {
+		writeSubsonicResponse(w, r, subsonicFail(0, err.Error()))
+		return
+	} else if !authorized {
+		writeSubsonicResponse(w, r, subsonicFail(50, "Not authorized for this song"))
+		return
+	}
+
+	env := subsonicOK()
+	env.Song = &subsonicSong{ID: song.ID, Title: song.Name, Artist: song.Composers}
+	writeSubsonicResponse(w, r, env)
+}
+
+// Stream handles GET /rest/stream.view, redirecting the client to the song's
+// underlying MusicURL so any Subsonic-compatible player can play it back.
+func (api *MusicListerAPI) Stream(w http.ResponseWriter, r *http.Request) {
+	user, ok := api.authenticateSubsonic(r)
+	if !ok {
+		writeSubsonicResponse(w, r, subsonicFail(40, "Wrong username or password"))
+		return
+	}
+
+	songID := r.URL.Query().Get("id")
+
+	song, exists, err := api.Store.Songs().Get(r.Context(), songID)
+	if err != nil {
+		writeSubsonicResponse(w, r, subsonicFail(0, err.Error()))
+		return
+	}
+	if !exists {
+		writeSubsonicResponse(w, r, subsonicFail(70, "Song not found"))
+		return
+	}
+	if authorized, err := api.subsonicUserCanAccessSong(r.Context(), user, song.ID); err != nil {
+		writeSubsonicResponse(w, r, subsonicFail(0, err.Error()))
+		return
+	} else if !authorized {
+		writeSubsonicResponse(w, r, subsonicFail(50, "Not authorized for this song"))
+		return
+	}
+
+	http.Redirect(w, r, song.MusicURL, http.StatusFound)
+}
+
+// registerSubsonicRoutes wires up the Subsonic-compatible surface so that
+// existing Subsonic clients (DSub, Symfonium, play:Sub, ...) can browse
+// MusicLister playlists without any client-side changes.
+func registerSubsonicRoutes(api *MusicListerAPI) {
+	http.HandleFunc("/rest/ping.view", api.Ping)
+	http.HandleFunc("/rest/getPlaylists.view", api.GetPlaylists)
+	http.HandleFunc("/rest/getPlaylist.view", api.GetPlaylist)
+	http.HandleFunc("/rest/createPlaylist.view", api.CreateSubsonicPlaylist)
+	http.HandleFunc("/rest/getSong.view", api.GetSong)
+	http.HandleFunc("/rest/stream.view", api.Stream)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func mustSaveUser(t *testing.T, store DataStore, user User) {
+	t.Helper()
+	if err := store.Users().Save(context.Background(), user); err != nil {
+		t.Fatalf("Save user: %v", err)
+	}
+}
+
+func subsonicAuthQuery(username, secretCode, salt string) string {
+	return "u=" + username + "&t=" + subsonicToken(secretCode, salt) + "&s=" + salt
+}
+
+func TestAuthenticateSubsonicValidatesToken(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+	mustSaveUser(t, store, User{ID: "user-1", SecretCode: "secret-1", Name: "ada"})
+
+	req := httptest.NewRequest(http.MethodGet, "/rest/ping.view?"+subsonicAuthQuery("ada", "secret-1", "saltysalt"), nil)
+	user, ok := api.authenticateSubsonic(req)
+	if !ok || user.ID != "user-1" {
+		t.Fatalf("authenticateSubsonic = (%+v, %v), want (user-1, true)", user, ok)
+	}
+}
+
+func TestAuthenticateSubsonicRejectsWrongToken(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+	mustSaveUser(t, store, User{ID: "user-1", SecretCode: "secret-1", Name: "ada"})
+
+	req := httptest.NewRequest(http.MethodGet, "/rest/ping.view?u=ada&t=deadbeef&s=saltysalt", nil)
+	if _, ok := api.authenticateSubsonic(req); ok {
+		t.Fatal("expected authenticateSubsonic to reject a token that doesn't match md5(SecretCode+salt)")
+	}
+}
+
+func TestAuthenticateSubsonicRequiresAllParams(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+	mustSaveUser(t, store, User{ID: "user-1", SecretCode: "secret-1", Name: "ada"})
+
+	req := httptest.NewRequest(http.MethodGet, "/rest/ping.view?u=ada", nil)
+	if _, ok := api.authenticateSubsonic(req); ok {
+		t.Fatal("expected authenticateSubsonic to reject a request missing t and s")
+	}
+}
+
+func TestWriteSubsonicResponseNegotiatesJSONAndXML(t *testing.T) {
+	xmlReq := httptest.NewRequest(http.MethodGet, "/rest/ping.view", nil)
+	xmlRec := httptest.NewRecorder()
+	writeSubsonicResponse(xmlRec, xmlReq, subsonicOK())
+	if ct := xmlRec.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Fatalf("Content-Type = %q, want application/xml", ct)
+	}
+	var env subsonicEnvelope
+	if err := xml.Unmarshal(xmlRec.Body.Bytes()[len(xml.Header):], &env); err != nil {
+		t.Fatalf("unmarshal xml: %v", err)
+	}
+	if env.Status != "ok" {
+		t.Fatalf("Status = %q, want ok", env.Status)
+	}
+
+	jsonReq := httptest.NewRequest(http.MethodGet, "/rest/ping.view?f=json", nil)
+	jsonRec := httptest.NewRecorder()
+	writeSubsonicResponse(jsonRec, jsonReq, subsonicOK())
+	if ct := jsonRec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+	if !strings.Contains(jsonRec.Body.String(), `"status":"ok"`) {
+		t.Fatalf("json body = %s, want it to contain status:ok", jsonRec.Body.String())
+	}
+}
+
+func TestGetPlaylistRejectsNonCollaborator(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+
+	mustSaveUser(t, store, User{ID: "owner-1", SecretCode: "owner-secret", Name: "owner"})
+	mustSaveUser(t, store, User{ID: "stranger-1", SecretCode: "stranger-secret", Name: "stranger"})
+	if err := store.Playlists().Save(context.Background(), Playlist{ID: "playlist-1", Name: "Private", UserID: "owner-1"}); err != nil {
+		t.Fatalf("Save playlist: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/rest/getPlaylist.view?id=playlist-1&"+subsonicAuthQuery("stranger", "stranger-secret", "salt"), nil)
+	rec := httptest.NewRecorder()
+	api.GetPlaylist(rec, req)
+
+	var env subsonicEnvelope
+	if err := xml.Unmarshal(rec.Body.Bytes()[len(xml.Header):], &env); err != nil {
+		t.Fatalf("unmarshal xml: %v", err)
+	}
+	if env.Status != "failed" || env.Error == nil {
+		t.Fatalf("GetPlaylist for a stranger = %+v, want a failed response", env)
+	}
+}
+
+func TestGetPlaylistAllowsOwner(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+
+	mustSaveUser(t, store, User{ID: "owner-1", SecretCode: "owner-secret", Name: "owner"})
+	if err := store.Playlists().Save(context.Background(), Playlist{ID: "playlist-1", Name: "Private", UserID: "owner-1"}); err != nil {
+		t.Fatalf("Save playlist: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/rest/getPlaylist.view?id=playlist-1&"+subsonicAuthQuery("owner", "owner-secret", "salt"), nil)
+	rec := httptest.NewRecorder()
+	api.GetPlaylist(rec, req)
+
+	var env subsonicEnvelope
+	if err := xml.Unmarshal(rec.Body.Bytes()[len(xml.Header):], &env); err != nil {
+		t.Fatalf("unmarshal xml: %v", err)
+	}
+	if env.Status != "ok" || env.Playlist == nil || env.Playlist.ID != "playlist-1" {
+		t.Fatalf("GetPlaylist for the owner = %+v, want an ok response with the playlist", env)
+	}
+}
+
+func TestGetSongAndStreamRejectNonCollaborator(t *testing.T) {
+	store := NewMemoryDataStore()
+	api := NewMusicListerAPI(store)
+
+	mustSaveUser(t, store, User{ID: "owner-1", SecretCode: "owner-secret", Name: "owner"})
+	mustSaveUser(t, store, User{ID: "stranger-1", SecretCode: "stranger-secret", Name: "stranger"})
+	song := Song{ID: "song-1", Name: "Clair de Lune", Composers: "Debussy", MusicURL: "https://example.com/clair-de-lune.mp3"}
+	if err := store.Songs().Save(context.Background(), song); err != nil {
+		t.Fatalf("Save song: %v", err)
+	}
+	if err := store.Playlists().Save(context.Background(), Playlist{ID: "playlist-1", UserID: "owner-1", Songs: []Song{song}}); err != nil {
+		t.Fatalf("Save playlist: %v", err)
+	}
+
+	getReq := httptest.NewRequest(http.MethodGet, "/rest/getSong.view?id=song-1&"+subsonicAuthQuery("stranger", "stranger-secret", "salt"), nil)
+	getRec := httptest.NewRecorder()
+	api.GetSong(getRec, getReq)
+
+	var getEnv subsonicEnvelope
+	if err := xml.Unmarshal(getRec.Body.Bytes()[len(xml.Header):], &getEnv); err != nil {
+		t.Fatalf("unmarshal xml: %v", err)
+	}
+	if getEnv.Status != "failed" {
+		t.Fatalf("GetSong for a stranger = %+v, want a failed response", getEnv)
+	}
+
+	streamReq := httptest.NewRequest(http.MethodGet, "/rest/stream.view?id=song-1&"+subsonicAuthQuery("stranger", "stranger-secret", "salt"), nil)
+	streamRec := httptest.NewRecorder()
+	api.Stream(streamRec, streamReq)
+	if streamRec.Code == http.StatusFound {
+		t.Fatal("expected Stream to refuse redirecting a stranger to another user's song")
+	}
+}
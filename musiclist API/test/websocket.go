@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// playlistHub fans out playlist-mutation notifications to subscribed
+// WebSocket clients, keyed by playlist ID.
+type playlistHub struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[*websocket.Conn]struct{}
+}
+
+// newPlaylistHub creates an empty hub.
+func newPlaylistHub() *playlistHub {
+	return &playlistHub{subscribers: make(map[string]map[*websocket.Conn]struct{})}
+}
+
+func (h *playlistHub) subscribe(playlistID string, conn *websocket.Conn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.subscribers[playlistID] == nil {
+		h.subscribers[playlistID] = make(map[*websocket.Conn]struct{})
+	}
+	h.subscribers[playlistID][conn] = struct{}{}
+}
+
+func (h *playlistHub) unsubscribe(playlistID string, conn *websocket.Conn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.subscribers[playlistID], conn)
+}
+
+type playlistRefreshMessage struct {
+	Type       string `json:"type"`
+	PlaylistID string `json:"playlistId"`
+}
+
+// broadcastPlaylistRefresh notifies every subscriber of playlistID that the
+// playlist changed, so clients know to re-fetch it.
+func (h *playlistHub) broadcastPlaylistRefresh(playlistID string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	msg := playlistRefreshMessage{Type: "playlistNeedRefresh", PlaylistID: playlistID}
+	for conn := range h.subscribers[playlistID] {
+		if err := conn.WriteJSON(msg); err != nil {
+			conn.Close()
+			delete(h.subscribers[playlistID], conn)
+		}
+	}
+}
+
+// PlaylistWebSocket handles GET /ws/playlist?playlistId=..., subscribing the
+// caller to live mutation notifications for a single playlist. The caller
+// must be authenticated and hold at least a viewer role on the playlist.
+func (api *MusicListerAPI) PlaylistWebSocket(w http.ResponseWriter, r *http.Request) {
+	playlistID := r.URL.Query().Get("playlistId")
+	if playlistID == "" {
+		http.Error(w, "playlistId is required", http.StatusBadRequest)
+		return
+	}
+
+	playlist, exists, err := api.Store.Playlists().Get(r.Context(), playlistID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Playlist not found", http.StatusNotFound)
+		return
+	}
+	if _, ok := roleOf(playlist, userFromContext(r).ID); !ok {
+		http.Error(w, "Not authorized for this playlist", http.StatusForbidden)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	api.Hub.subscribe(playlistID, conn)
+	defer api.Hub.unsubscribe(playlistID, conn)
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+}